@@ -0,0 +1,268 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// Attachment describes a file attached to a piece of content. It is
+// populated from the nested `extensions` and `_links.download` fields
+// Confluence returns alongside the attachment's own id/title/version.
+type Attachment struct {
+	Id           string
+	Title        string
+	MediaType    string
+	FileSize     int64
+	DownloadLink string
+	Version      Version
+}
+
+func (a *Attachment) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Id      string  `json:"id"`
+		Title   string  `json:"title"`
+		Version Version `json:"version"`
+
+		Extensions struct {
+			MediaType string `json:"mediaType"`
+			FileSize  int64  `json:"fileSize"`
+		} `json:"extensions"`
+
+		Links struct {
+			Download string `json:"download"`
+		} `json:"_links"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	a.Id = raw.Id
+	a.Title = raw.Title
+	a.Version = raw.Version
+	a.MediaType = raw.Extensions.MediaType
+	a.FileSize = raw.Extensions.FileSize
+	a.DownloadLink = raw.Links.Download
+	return nil
+}
+
+type AttachmentResult struct {
+	Attachments []Attachment `json:"results,omitempty"`
+	Start       int          `json:"start,omitempty"`
+	Limit       int          `json:"limit,omitempty"`
+	Size        int          `json:"size,omitempty"`
+	Links       Links        `json:"_links,omitempty"`
+}
+
+// GetAttachments lists the attachments of request.Page.
+func (w *Wiki) GetAttachments(request PageRequest, expand []string) (*[]Attachment, error) {
+	return w.GetAttachmentsWithContext(context.Background(), request, expand)
+}
+
+// GetAttachmentsWithContext is GetAttachments, but aborts the pagination
+// walk as soon as ctx is canceled or its deadline is exceeded. On
+// cancellation it still returns whatever attachments had already been
+// fetched, alongside the context error.
+func (w *Wiki) GetAttachmentsWithContext(ctx context.Context, request PageRequest, expand []string) (*[]Attachment, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	attachments, err := CollectAttachments(ctx, w.NewAttachmentIterator(request, expand))
+	return &attachments, err
+}
+
+// AttachmentIterator lazily walks the attachments of a GetAttachments
+// result, the same way PageIterator walks child pages.
+type AttachmentIterator struct {
+	paginationCursor
+	buf []Attachment
+}
+
+// NewAttachmentIterator creates an AttachmentIterator over request.Page's
+// attachments.
+func (w *Wiki) NewAttachmentIterator(request PageRequest, expand []string) *AttachmentIterator {
+	return &AttachmentIterator{paginationCursor: paginationCursor{
+		w: w, pageID: request.Page.Id, expand: expand, start: request.Start, limit: request.Limit,
+	}}
+}
+
+// Next returns the next attachment, or io.EOF once the walk is exhausted.
+func (it *AttachmentIterator) Next(ctx context.Context) (*Attachment, error) {
+	for len(it.buf) == 0 {
+		if it.exhausted {
+			return nil, io.EOF
+		}
+		if err := it.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	att := it.buf[0]
+	it.buf = it.buf[1:]
+	return &att, nil
+}
+
+func (it *AttachmentIterator) fetch(ctx context.Context) error {
+	reqURL, err := it.requestURL("/child/attachment")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := it.w.sendRequest(req)
+	if err != nil {
+		return err
+	}
+
+	var result AttachmentResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return err
+	}
+
+	it.buf = result.Attachments
+	return it.advance(result.Links, result.Size)
+}
+
+// CollectAttachments drains it into a slice, for callers who want
+// GetAttachments's all-at-once behavior. On error it still returns
+// whatever attachments had already been collected.
+func CollectAttachments(ctx context.Context, it *AttachmentIterator) ([]Attachment, error) {
+	var attachments []Attachment
+	for {
+		att, err := it.Next(ctx)
+		if err == io.EOF {
+			return attachments, nil
+		}
+		if err != nil {
+			return attachments, err
+		}
+		attachments = append(attachments, *att)
+	}
+}
+
+// DownloadAttachment streams att's bytes from Confluence without buffering
+// them in memory; the caller is responsible for closing the returned
+// ReadCloser.
+func (w *Wiki) DownloadAttachment(att *Attachment) (io.ReadCloser, error) {
+	return w.DownloadAttachmentWithContext(context.Background(), att)
+}
+
+// DownloadAttachmentWithContext is DownloadAttachment, but aborts the
+// request as soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) DownloadAttachmentWithContext(ctx context.Context, att *Attachment) (io.ReadCloser, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	downloadEndPoint, err := w.endPoint.Parse(att.DownloadLink)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadEndPoint.String(), nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	res, err := w.doRequest(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &cancelOnCloseReader{ReadCloser: res.Body, cancel: cancel}, nil
+}
+
+// cancelOnCloseReader releases a deadlineContext's resources once the
+// caller is done reading the response body.
+type cancelOnCloseReader struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelOnCloseReader) Close() error {
+	defer r.cancel()
+	return r.ReadCloser.Close()
+}
+
+// UpdateAttachmentData replaces an attachment's bytes, streaming r the same
+// way AddAttachmentReader does.
+func (w *Wiki) UpdateAttachmentData(contentID, attachmentID string, r io.Reader, filename, comment string) (*Attachment, error) {
+	return w.UpdateAttachmentDataWithContext(context.Background(), contentID, attachmentID, r, filename, comment)
+}
+
+// UpdateAttachmentDataWithContext is UpdateAttachmentData, but aborts the
+// request as soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) UpdateAttachmentDataWithContext(ctx context.Context, contentID, attachmentID string, r io.Reader, filename, comment string) (*Attachment, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	endPoint, err := w.contentEndpoint(contentID + "/child/attachment/" + attachmentID + "/data")
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endPoint.String(), pr)
+	if err != nil {
+		pw.Close()
+		return nil, err
+	}
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.Header.Add("X-Atlassian-Token", "no-check")
+
+	go func() {
+		err := func() error {
+			part, err := writer.CreateFormFile("file", filename)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(part, r); err != nil {
+				return err
+			}
+
+			if comment != "" {
+				if err := writer.WriteField("comment", comment); err != nil {
+					return err
+				}
+			}
+
+			return writer.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	res, err := w.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var att Attachment
+	err = json.Unmarshal(res, &att)
+	if err != nil {
+		return nil, err
+	}
+
+	return &att, nil
+}
+
+// DeleteAttachment deletes an attachment. Attachments are content objects
+// in Confluence, so this mirrors DeleteContent exactly.
+func (w *Wiki) DeleteAttachment(attachmentID string) error {
+	return w.DeleteAttachmentWithContext(context.Background(), attachmentID)
+}
+
+// DeleteAttachmentWithContext is DeleteAttachment, but aborts the request
+// as soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) DeleteAttachmentWithContext(ctx context.Context, attachmentID string) error {
+	return w.DeleteContentWithContext(ctx, attachmentID)
+}
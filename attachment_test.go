@@ -0,0 +1,29 @@
+package confluence
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAttachmentIteratorFollowsLinksNext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "next" {
+			rw.Write([]byte(`{"results":[{"id":"att2","title":"b.png"}],"size":1,"limit":5}`))
+			return
+		}
+		rw.Write([]byte(`{"results":[{"id":"att1","title":"a.png"}],"size":1,"limit":5,"_links":{"next":"/wiki/rest/api/content/1/child/attachment?cursor=next"}}`))
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL+"/wiki/rest/api", nil)
+
+	attachments, err := w.GetAttachments(PageRequest{Page: &Content{Id: "1"}, Limit: 5}, nil)
+	if err != nil {
+		t.Fatalf("GetAttachments: %v", err)
+	}
+
+	if len(*attachments) != 2 || (*attachments)[0].Id != "att1" || (*attachments)[1].Id != "att2" {
+		t.Fatalf("attachments = %+v, want [att1 att2]", *attachments)
+	}
+}
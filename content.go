@@ -1,8 +1,7 @@
 package confluence
 
 import (
-	"strconv"
-	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"mime/multipart"
@@ -11,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Storage struct {
@@ -24,6 +24,30 @@ type Body struct {
 
 type Version struct {
 	Number int `json:"number"`
+
+	// When, By, Message, and MinorEdit are only ever populated when
+	// decoding a server response (GetVersion, GetVersions). When and By
+	// are pointers so that omitempty actually suppresses them on the
+	// create/update request bodies that reuse this struct as
+	// Content.Version, which JSON's omitempty can't do for a plain
+	// struct's zero value; Message and MinorEdit are omitted there by
+	// omitempty as plain zero values.
+	When      *time.Time `json:"when,omitempty"`
+	By        *User      `json:"by,omitempty"`
+	Message   string     `json:"message,omitempty"`
+	MinorEdit bool       `json:"minorEdit,omitempty"`
+
+	// Content is only populated when GetVersion is called with an
+	// expand of "content.body.storage", and holds that prior revision's
+	// storage-format body.
+	Content *Content `json:"content,omitempty"`
+}
+
+type User struct {
+	Type        string `json:"type,omitempty"`
+	AccountID   string `json:"accountId,omitempty"`
+	Username    string `json:"username,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
 }
 
 type Ancestor struct {
@@ -46,6 +70,18 @@ type Content struct {
 	LabelPrefix string     `json:"prefix,omitempty"`
 	LabelName   string     `json:"name,omitempty"`
 	Attachments []string
+
+	// IdempotencyKey, when set, is sent as the Idempotency-Key header on
+	// CreateContent so a retried create can't double-create the page.
+	IdempotencyKey string `json:"-"`
+}
+
+// Links holds the HATEOAS links Confluence attaches to paginated
+// responses. Next, when present, is the path (relative to the site root)
+// of the next page of results and should be preferred over computing
+// start+limit by hand.
+type Links struct {
+	Next string `json:"next,omitempty"`
 }
 
 type PageResult struct {
@@ -53,6 +89,7 @@ type PageResult struct {
 	Start int       `json:"start,omitempty"`
 	Limit int       `json:"limt,omitempty"`
 	Size  int       `json:"size,omitempty"`
+	Links Links     `json:"_links,omitempty"`
 }
 
 type Label struct {
@@ -64,6 +101,7 @@ type LabelResult struct {
 	Start int       `json:"start,omitempty"`
 	Limit int       `json:"limt,omitempty"`
 	Size  int       `json:"size,omitempty"`
+	Links Links     `json:"_links,omitempty"`
 }
 
 type PageRequest struct {
@@ -77,12 +115,21 @@ func (w *Wiki) contentEndpoint(contentID string) (*url.URL, error) {
 }
 
 func (w *Wiki) DeleteContent(contentID string) error {
+	return w.DeleteContentWithContext(context.Background(), contentID)
+}
+
+// DeleteContentWithContext is DeleteContent, but aborts the request as soon
+// as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) DeleteContentWithContext(ctx context.Context, contentID string) error {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
 	contentEndPoint, err := w.contentEndpoint(contentID)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("DELETE", contentEndPoint.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", contentEndPoint.String(), nil)
 	if err != nil {
 		return err
 	}
@@ -95,6 +142,15 @@ func (w *Wiki) DeleteContent(contentID string) error {
 }
 
 func (w *Wiki) GetContent(contentID string, expand []string) (*Content, error) {
+	return w.GetContentWithContext(context.Background(), contentID, expand)
+}
+
+// GetContentWithContext is GetContent, but aborts the request as soon as
+// ctx is canceled or its deadline is exceeded.
+func (w *Wiki) GetContentWithContext(ctx context.Context, contentID string, expand []string) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
 	contentEndPoint, err := w.contentEndpoint(contentID)
 	if err != nil {
 		return nil, err
@@ -103,7 +159,7 @@ func (w *Wiki) GetContent(contentID string, expand []string) (*Content, error) {
 	data.Set("expand", strings.Join(expand, ","))
 	contentEndPoint.RawQuery = data.Encode()
 
-	req, err := http.NewRequest("GET", contentEndPoint.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", contentEndPoint.String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -123,57 +179,46 @@ func (w *Wiki) GetContent(contentID string, expand []string) (*Content, error) {
 }
 
 func (w *Wiki) GetChildPages(request PageRequest, expand []string) (*[]Content, error) {
-	contentEndPoint, err := w.contentEndpoint(request.Page.Id + "/child/page")
-	if err != nil {
-		return nil, err
-	}
-	data := url.Values{}
-	data.Set("expand", strings.Join(expand, ","))
-	data.Set("start", strconv.Itoa(request.Start))
-	data.Set("limit", strconv.Itoa(request.Limit))
-	contentEndPoint.RawQuery = data.Encode()
+	return w.GetChildPagesWithContext(context.Background(), request, expand)
+}
 
-	req, err := http.NewRequest("GET", contentEndPoint.String(), nil)
-	if err != nil {
-		return nil, err
-	}
+// GetChildPagesWithContext is GetChildPages, but aborts the pagination walk
+// as soon as ctx is canceled or its deadline is exceeded. On cancellation
+// it still returns whatever pages had already been fetched, alongside the
+// context error, so callers can salvage partial work instead of discarding
+// it.
+func (w *Wiki) GetChildPagesWithContext(ctx context.Context, request PageRequest, expand []string) (*[]Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	pages, err := Collect(ctx, w.NewPageIterator(request, expand))
+	return &pages, err
+}
 
-	res, err := w.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+func (w *Wiki) UpdateContent(content *Content) (*Content, error) {
+	return w.UpdateContentWithContext(context.Background(), content)
+}
 
-	var pages []Content
+// UpdateContentWithContext is UpdateContent, but aborts the request as soon
+// as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) UpdateContentWithContext(ctx context.Context, content *Content) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
 
-	var result PageResult
-	err = json.Unmarshal(res, &result)
+	jsonbody, err := json.Marshal(content)
 	if err != nil {
 		return nil, err
 	}
 
-	pages = append(pages, result.Pages...)
-
-	if result.Size > 0 && result.Size == request.Limit {
-		r := PageRequest{Page: request.Page, Start: request.Start + result.Size, Limit: request.Limit}
-		addPages, err := w.GetChildPages(r, expand)
-		if err != nil {
-			return nil, err
-		}
-
-		pages = append(pages, *addPages...)
+	contentEndPoint, err := w.contentEndpoint(content.Id)
+	if err != nil {
+		return nil, err
 	}
 
-	return &pages, nil
-}
-
-func (w *Wiki) UpdateContent(content *Content) (*Content, error) {
-	jsonbody, err := json.Marshal(content)
+	req, err := http.NewRequestWithContext(ctx, "PUT", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
 	if err != nil {
 		return nil, err
 	}
-
-	contentEndPoint, err := w.contentEndpoint(content.Id)
-	req, err := http.NewRequest("PUT", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
 	req.Header.Add("Content-Type", "application/json")
 
 	res, err := w.sendRequest(req)
@@ -191,14 +236,33 @@ func (w *Wiki) UpdateContent(content *Content) (*Content, error) {
 }
 
 func (w *Wiki) CreateContent(content *Content) (*Content, error) {
+	return w.CreateContentWithContext(context.Background(), content)
+}
+
+// CreateContentWithContext is CreateContent, but aborts the request as soon
+// as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) CreateContentWithContext(ctx context.Context, content *Content) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
 	jsonbody, err := json.Marshal(content)
 	if err != nil {
 		return nil, err
 	}
 
 	contentEndPoint, err := w.contentEndpoint("")
-	req, err := http.NewRequest("POST", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Content-Type", "application/json")
+	if content.IdempotencyKey != "" {
+		req.Header.Add("Idempotency-Key", content.IdempotencyKey)
+	}
 
 	res, err := w.sendRequest(req)
 	if err != nil {
@@ -215,13 +279,29 @@ func (w *Wiki) CreateContent(content *Content) (*Content, error) {
 }
 
 func (w *Wiki) AddLabel(content *Content) (*Content, error) {
+	return w.AddLabelWithContext(context.Background(), content)
+}
+
+// AddLabelWithContext is AddLabel, but aborts the request as soon as ctx is
+// canceled or its deadline is exceeded.
+func (w *Wiki) AddLabelWithContext(ctx context.Context, content *Content) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
 	jsonbody, err := json.Marshal(content)
 	if err != nil {
 		return nil, err
 	}
 
 	contentEndPoint, err := w.contentEndpoint(content.Id + "/label")
-	req, err := http.NewRequest("POST", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", contentEndPoint.String(), strings.NewReader(string(jsonbody)))
+	if err != nil {
+		return nil, err
+	}
 	req.Header.Add("Content-Type", "application/json")
 
 	res, err := w.sendRequest(req)
@@ -239,91 +319,126 @@ func (w *Wiki) AddLabel(content *Content) (*Content, error) {
 }
 
 func (w *Wiki) AddAttachments(content *Content) (*Content, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	for _, file := range content.Attachments {
-		f, err := os.Open(file)
-		if err != nil {
-			continue
-		}
-		defer f.Close()
-
-		part, err := writer.CreateFormFile("file", filepath.Base(file))
-		if err != nil {
-			return nil, err
-		}
-
-		_, err = io.Copy(part, f)
-		if err != nil {
-			return nil, err
-		}
-	}
+	return w.AddAttachmentsWithContext(context.Background(), content)
+}
 
-	err := writer.Close()
-	if err != nil {
-		return nil, err
-	}
+// AddAttachmentsWithContext is AddAttachments, but aborts the request as
+// soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) AddAttachmentsWithContext(ctx context.Context, content *Content) (*Content, error) {
+	return w.postAttachment(ctx, content.Id+"/child/attachment", func(writer *multipart.Writer, pw *io.PipeWriter) {
+		err := func() error {
+			for _, file := range content.Attachments {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+
+				part, err := writer.CreateFormFile("file", filepath.Base(file))
+				if err != nil {
+					f.Close()
+					return err
+				}
+
+				_, err = io.Copy(part, f)
+				f.Close()
+				if err != nil {
+					return err
+				}
+			}
+			return writer.Close()
+		}()
+		pw.CloseWithError(err)
+	})
+}
 
-	contentEndPoint, err := w.contentEndpoint(content.Id + "/child/attachment")
-	req, err := http.NewRequest("POST", contentEndPoint.String(), body)
-	req.Header.Add("Content-Type", writer.FormDataContentType())
-	req.Header.Add("X-Atlassian-Token", "no-check")
+// AddAttachmentReader uploads r under filename as an attachment of the
+// content with the given ID, without requiring callers to write it to a
+// temporary file first. comment, if non-empty, is attached as the
+// attachment's comment.
+func (w *Wiki) AddAttachmentReader(contentID, filename string, r io.Reader, comment string) (*Content, error) {
+	return w.AddAttachmentReaderWithContext(context.Background(), contentID, filename, r, comment)
+}
 
-	res, err := w.sendRequest(req)
-	if err != nil {
-		return nil, err
-	}
+// AddAttachmentReaderWithContext is AddAttachmentReader, but aborts the
+// request as soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) AddAttachmentReaderWithContext(ctx context.Context, contentID, filename string, r io.Reader, comment string) (*Content, error) {
+	return w.postAttachment(ctx, contentID+"/child/attachment", func(writer *multipart.Writer, pw *io.PipeWriter) {
+		err := func() error {
+			part, err := writer.CreateFormFile("file", filename)
+			if err != nil {
+				return err
+			}
+
+			if _, err := io.Copy(part, r); err != nil {
+				return err
+			}
+
+			if comment != "" {
+				if err := writer.WriteField("comment", comment); err != nil {
+					return err
+				}
+			}
+
+			return writer.Close()
+		}()
+		pw.CloseWithError(err)
+	})
+}
 
-	var newContent Content
-	err = json.Unmarshal(res, &newContent)
+// postAttachment builds and issues a multipart-encoded attachment upload
+// against the content/child/attachment endpoint rooted at path, streaming
+// the body via an io.Pipe. write is only started in its own goroutine once
+// the request has been built successfully, so a failure in contentEndpoint
+// or NewRequestWithContext can't leave write blocked forever on a pipe
+// nobody will ever read.
+func (w *Wiki) postAttachment(ctx context.Context, path string, write func(writer *multipart.Writer, pw *io.PipeWriter)) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	contentEndPoint, err := w.contentEndpoint(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return &newContent, nil
-}
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
 
-func (w *Wiki) GetLabel(request PageRequest, expand []string) (*[]Label, error) {
-	contentEndPoint, err := w.contentEndpoint(request.Page.Id + "/label")
+	req, err := http.NewRequestWithContext(ctx, "POST", contentEndPoint.String(), pr)
 	if err != nil {
+		pw.Close()
 		return nil, err
 	}
-	data := url.Values{}
-	data.Set("expand", strings.Join(expand, ","))
-	data.Set("start", strconv.Itoa(request.Start))
-	data.Set("limit", strconv.Itoa(request.Limit))
-	contentEndPoint.RawQuery = data.Encode()
+	req.Header.Add("Content-Type", writer.FormDataContentType())
+	req.Header.Add("X-Atlassian-Token", "no-check")
 
-	req, err := http.NewRequest("GET", contentEndPoint.String(), nil)
-	if err != nil {
-		return nil, err
-	}
+	go write(writer, pw)
 
 	res, err := w.sendRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	var labels []Label
-
-	var result LabelResult
-	err = json.Unmarshal(res, &result)
+	var newContent Content
+	err = json.Unmarshal(res, &newContent)
 	if err != nil {
 		return nil, err
 	}
 
-	labels = append(labels, result.Labels...)
+	return &newContent, nil
+}
 
-	if result.Size > 0 && result.Size == request.Limit {
-		r := PageRequest{Page: request.Page, Start: request.Start + result.Size, Limit: request.Limit}
-		addLabels, err := w.GetLabel(r, expand)
-		if err != nil {
-			return nil, err
-		}
+func (w *Wiki) GetLabel(request PageRequest, expand []string) (*[]Label, error) {
+	return w.GetLabelWithContext(context.Background(), request, expand)
+}
 
-		labels = append(labels, *addLabels...)
-	}
+// GetLabelWithContext is GetLabel, but aborts the pagination walk as soon
+// as ctx is canceled or its deadline is exceeded. On cancellation it still
+// returns whatever labels had already been fetched, alongside the context
+// error, so callers can salvage partial work instead of discarding it.
+func (w *Wiki) GetLabelWithContext(ctx context.Context, request PageRequest, expand []string) (*[]Label, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
 
-	return &labels, nil
+	labels, err := CollectLabels(ctx, w.NewLabelIterator(request, expand))
+	return &labels, err
 }
@@ -0,0 +1,254 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Search runs a CQL (Confluence Query Language) query against
+// /content/search, e.g. `Search("space = DEV and label = \"published\"",
+// nil, 0, 25)`.
+func (w *Wiki) Search(cql string, expand []string, start, limit int) (*PageResult, error) {
+	return w.SearchWithContext(context.Background(), cql, expand, start, limit)
+}
+
+// SearchWithContext is Search, but aborts the request as soon as ctx is
+// canceled or its deadline is exceeded.
+func (w *Wiki) SearchWithContext(ctx context.Context, cql string, expand []string, start, limit int) (*PageResult, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	searchEndPoint, err := w.contentEndpoint("search")
+	if err != nil {
+		return nil, err
+	}
+	data := url.Values{}
+	data.Set("cql", cql)
+	data.Set("expand", strings.Join(expand, ","))
+	data.Set("start", strconv.Itoa(start))
+	data.Set("limit", strconv.Itoa(limit))
+	searchEndPoint.RawQuery = data.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searchEndPoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := w.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result PageResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// paginationCursor holds the state shared by every lazy pagination
+// iterator in this package (PageIterator, LabelIterator, AttachmentIterator):
+// it prefers the `_links.next` URL Confluence returns and falls back to
+// start+limit arithmetic only when the server doesn't supply one.
+type paginationCursor struct {
+	w      *Wiki
+	pageID string
+	expand []string
+	start  int
+	limit  int
+
+	nextURL   string
+	exhausted bool
+}
+
+// requestURL returns the URL for the next fetch: the server-provided
+// `_links.next` URL if one is pending, otherwise a freshly built
+// start+limit query against contentEndpoint(pageID + suffix).
+func (c *paginationCursor) requestURL(suffix string) (string, error) {
+	if c.nextURL != "" {
+		return c.nextURL, nil
+	}
+
+	endPoint, err := c.w.contentEndpoint(c.pageID + suffix)
+	if err != nil {
+		return "", err
+	}
+	data := url.Values{}
+	data.Set("expand", strings.Join(c.expand, ","))
+	data.Set("start", strconv.Itoa(c.start))
+	data.Set("limit", strconv.Itoa(c.limit))
+	endPoint.RawQuery = data.Encode()
+	return endPoint.String(), nil
+}
+
+// advance updates the cursor after a fetch that returned size results and
+// the given links, deciding whether another page follows.
+func (c *paginationCursor) advance(links Links, size int) error {
+	c.nextURL = ""
+
+	switch {
+	case links.Next != "":
+		next, err := c.w.endPoint.Parse(links.Next)
+		if err != nil {
+			return err
+		}
+		c.nextURL = next.String()
+	case size > 0 && size == c.limit:
+		c.start += size
+	default:
+		c.exhausted = true
+	}
+
+	return nil
+}
+
+// PageIterator lazily walks the pages of a GetChildPages result. Unlike
+// driving GetChildPages's old recursion by hand, Next does not grow the
+// call stack with the result count and can be canceled via ctx mid-walk.
+type PageIterator struct {
+	paginationCursor
+	buf []Content
+}
+
+// NewPageIterator creates a PageIterator over request.Page's child pages.
+func (w *Wiki) NewPageIterator(request PageRequest, expand []string) *PageIterator {
+	return &PageIterator{paginationCursor: paginationCursor{
+		w: w, pageID: request.Page.Id, expand: expand, start: request.Start, limit: request.Limit,
+	}}
+}
+
+// Next returns the next child page, or io.EOF once the walk is exhausted.
+func (it *PageIterator) Next(ctx context.Context) (*Content, error) {
+	for len(it.buf) == 0 {
+		if it.exhausted {
+			return nil, io.EOF
+		}
+		if err := it.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	page := it.buf[0]
+	it.buf = it.buf[1:]
+	return &page, nil
+}
+
+func (it *PageIterator) fetch(ctx context.Context) error {
+	reqURL, err := it.requestURL("/child/page")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := it.w.sendRequest(req)
+	if err != nil {
+		return err
+	}
+
+	var result PageResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return err
+	}
+
+	it.buf = result.Pages
+	return it.advance(result.Links, result.Size)
+}
+
+// Collect drains it into a slice, for callers who want GetChildPages's old
+// all-at-once behavior. On error it still returns whatever pages had
+// already been collected.
+func Collect(ctx context.Context, it *PageIterator) ([]Content, error) {
+	var pages []Content
+	for {
+		page, err := it.Next(ctx)
+		if err == io.EOF {
+			return pages, nil
+		}
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, *page)
+	}
+}
+
+// LabelIterator is PageIterator's counterpart for GetLabel's label listing.
+type LabelIterator struct {
+	paginationCursor
+	buf []Label
+}
+
+// NewLabelIterator creates a LabelIterator over request.Page's labels.
+func (w *Wiki) NewLabelIterator(request PageRequest, expand []string) *LabelIterator {
+	return &LabelIterator{paginationCursor: paginationCursor{
+		w: w, pageID: request.Page.Id, expand: expand, start: request.Start, limit: request.Limit,
+	}}
+}
+
+// Next returns the next label, or io.EOF once the walk is exhausted.
+func (it *LabelIterator) Next(ctx context.Context) (*Label, error) {
+	for len(it.buf) == 0 {
+		if it.exhausted {
+			return nil, io.EOF
+		}
+		if err := it.fetch(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	label := it.buf[0]
+	it.buf = it.buf[1:]
+	return &label, nil
+}
+
+func (it *LabelIterator) fetch(ctx context.Context) error {
+	reqURL, err := it.requestURL("/label")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := it.w.sendRequest(req)
+	if err != nil {
+		return err
+	}
+
+	var result LabelResult
+	if err := json.Unmarshal(res, &result); err != nil {
+		return err
+	}
+
+	it.buf = result.Labels
+	return it.advance(result.Links, result.Size)
+}
+
+// CollectLabels drains it into a slice, for callers who want GetLabel's old
+// all-at-once behavior. On error it still returns whatever labels had
+// already been collected.
+func CollectLabels(ctx context.Context, it *LabelIterator) ([]Label, error) {
+	var labels []Label
+	for {
+		label, err := it.Next(ctx)
+		if err == io.EOF {
+			return labels, nil
+		}
+		if err != nil {
+			return labels, err
+		}
+		labels = append(labels, *label)
+	}
+}
@@ -0,0 +1,66 @@
+package confluence
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPageIteratorFollowsLinksNextEvenOnShortPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "abc" {
+			rw.Write([]byte(`{"results":[{"id":"2"}],"size":1,"limit":2}`))
+			return
+		}
+		// Confluence can return fewer results than the requested limit
+		// while still pointing at another page via _links.next; the old
+		// start+limit-only termination missed this and stopped early.
+		rw.Write([]byte(`{"results":[{"id":"1"}],"size":1,"limit":2,"_links":{"next":"/wiki/rest/api/content/1/child/page?cursor=abc"}}`))
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL+"/wiki/rest/api", nil)
+
+	pages, err := Collect(context.Background(), w.NewPageIterator(PageRequest{Page: &Content{Id: "1"}, Limit: 2}, nil))
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	var ids []string
+	for _, p := range pages {
+		ids = append(ids, p.Id)
+	}
+	if len(ids) != 2 || ids[0] != "1" || ids[1] != "2" {
+		t.Fatalf("page ids = %v, want [1 2]", ids)
+	}
+}
+
+func TestPageIteratorFallsBackToStartLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("start") {
+		case "0":
+			rw.Write([]byte(`{"results":[{"id":"1"},{"id":"2"}],"size":2,"limit":2}`))
+		case "2":
+			rw.Write([]byte(`{"results":[{"id":"3"}],"size":1,"limit":2}`))
+		default:
+			t.Errorf("unexpected start=%q", r.URL.Query().Get("start"))
+		}
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL+"/wiki/rest/api", nil)
+
+	pages, err := Collect(context.Background(), w.NewPageIterator(PageRequest{Page: &Content{Id: "1"}, Limit: 2}, nil))
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+
+	var ids []string
+	for _, p := range pages {
+		ids = append(ids, p.Id)
+	}
+	if len(ids) != 3 || ids[0] != "1" || ids[1] != "2" || ids[2] != "3" {
+		t.Fatalf("page ids = %v, want [1 2 3]", ids)
+	}
+}
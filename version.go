@@ -0,0 +1,232 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type VersionResult struct {
+	Versions []Version `json:"results,omitempty"`
+	Start    int       `json:"start,omitempty"`
+	Limit    int       `json:"limit,omitempty"`
+	Size     int       `json:"size,omitempty"`
+	Links    Links     `json:"_links,omitempty"`
+}
+
+// GetVersions lists the version history of a piece of content, most recent
+// first.
+func (w *Wiki) GetVersions(contentID string, start, limit int) (*VersionResult, error) {
+	return w.GetVersionsWithContext(context.Background(), contentID, start, limit)
+}
+
+// GetVersionsWithContext is GetVersions, but aborts the request as soon as
+// ctx is canceled or its deadline is exceeded.
+func (w *Wiki) GetVersionsWithContext(ctx context.Context, contentID string, start, limit int) (*VersionResult, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	versionEndPoint, err := w.contentEndpoint(contentID + "/version")
+	if err != nil {
+		return nil, err
+	}
+	data := url.Values{}
+	data.Set("start", strconv.Itoa(start))
+	data.Set("limit", strconv.Itoa(limit))
+	versionEndPoint.RawQuery = data.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", versionEndPoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := w.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result VersionResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetVersion fetches a single prior version of a piece of content, with its
+// storage-format body expanded so callers can inspect or diff it.
+func (w *Wiki) GetVersion(contentID string, number int) (*Version, error) {
+	return w.GetVersionWithContext(context.Background(), contentID, number)
+}
+
+// GetVersionWithContext is GetVersion, but aborts the request as soon as
+// ctx is canceled or its deadline is exceeded.
+func (w *Wiki) GetVersionWithContext(ctx context.Context, contentID string, number int) (*Version, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	versionEndPoint, err := w.contentEndpoint(contentID + "/version/" + strconv.Itoa(number))
+	if err != nil {
+		return nil, err
+	}
+	data := url.Values{}
+	data.Set("expand", "content.body.storage")
+	versionEndPoint.RawQuery = data.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", versionEndPoint.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := w.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var version Version
+	err = json.Unmarshal(res, &version)
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+type restoreVersionRequest struct {
+	OperationKey string               `json:"operationKey"`
+	Params       restoreVersionParams `json:"params"`
+}
+
+type restoreVersionParams struct {
+	VersionNumber int    `json:"versionNumber"`
+	Message       string `json:"message,omitempty"`
+}
+
+// RestoreVersion reverts content to a prior version, recording message as
+// the reason for the new version this creates.
+func (w *Wiki) RestoreVersion(contentID string, number int, message string) (*Content, error) {
+	return w.RestoreVersionWithContext(context.Background(), contentID, number, message)
+}
+
+// RestoreVersionWithContext is RestoreVersion, but aborts the request as
+// soon as ctx is canceled or its deadline is exceeded.
+func (w *Wiki) RestoreVersionWithContext(ctx context.Context, contentID string, number int, message string) (*Content, error) {
+	ctx, cancel := w.deadlineContext(ctx)
+	defer cancel()
+
+	jsonbody, err := json.Marshal(restoreVersionRequest{
+		OperationKey: "RESTORE",
+		Params: restoreVersionParams{
+			VersionNumber: number,
+			Message:       message,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versionEndPoint, err := w.contentEndpoint(contentID + "/version")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", versionEndPoint.String(), strings.NewReader(string(jsonbody)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	res, err := w.sendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var newContent Content
+	err = json.Unmarshal(res, &newContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &newContent, nil
+}
+
+// DiffOp identifies the kind of change a DiffHunk represents.
+type DiffOp int
+
+const (
+	DiffEqual DiffOp = iota
+	DiffInsert
+	DiffDelete
+)
+
+// DiffHunk is one line of a line-oriented diff between two storage-format
+// bodies.
+type DiffHunk struct {
+	Op   DiffOp
+	Text string
+}
+
+// DiffStorage diffs two storage-format (XHTML) bodies line by line using a
+// longest-common-subsequence alignment, so callers can show what changed
+// between two page versions without pulling in a separate diff library.
+func DiffStorage(oldBody, newBody string) ([]DiffHunk, error) {
+	var oldLines, newLines []string
+	if oldBody != "" {
+		oldLines = strings.Split(oldBody, "\n")
+	}
+	if newBody != "" {
+		newLines = strings.Split(newBody, "\n")
+	}
+
+	lcs := lcsTable(oldLines, newLines)
+
+	var hunks []DiffHunk
+	i, j := len(oldLines), len(newLines)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && oldLines[i-1] == newLines[j-1]:
+			hunks = append(hunks, DiffHunk{Op: DiffEqual, Text: oldLines[i-1]})
+			i--
+			j--
+		case j > 0 && (i == 0 || lcs[i][j-1] >= lcs[i-1][j]):
+			hunks = append(hunks, DiffHunk{Op: DiffInsert, Text: newLines[j-1]})
+			j--
+		default:
+			hunks = append(hunks, DiffHunk{Op: DiffDelete, Text: oldLines[i-1]})
+			i--
+		}
+	}
+
+	for l, r := 0, len(hunks)-1; l < r; l, r = l+1, r-1 {
+		hunks[l], hunks[r] = hunks[r], hunks[l]
+	}
+
+	return hunks, nil
+}
+
+// lcsTable builds the standard dynamic-programming longest-common-
+// subsequence length table over a and b.
+func lcsTable(a, b []string) [][]int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				table[i][j] = table[i-1][j-1] + 1
+			} else if table[i-1][j] >= table[i][j-1] {
+				table[i][j] = table[i-1][j]
+			} else {
+				table[i][j] = table[i][j-1]
+			}
+		}
+	}
+
+	return table
+}
@@ -0,0 +1,69 @@
+package confluence
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestContentVersionOmitsReadOnlyFieldsOnMarshal(t *testing.T) {
+	jsonbody, err := json.Marshal(&Content{Title: "new page"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	for _, field := range []string{`"when"`, `"by"`} {
+		if strings.Contains(string(jsonbody), field) {
+			t.Fatalf("marshaled Content contains %s, want it omitted: %s", field, jsonbody)
+		}
+	}
+}
+
+func TestDiffStorageEmptyBodies(t *testing.T) {
+	hunks, err := DiffStorage("", "")
+	if err != nil {
+		t.Fatalf("DiffStorage: %v", err)
+	}
+	if len(hunks) != 0 {
+		t.Fatalf("hunks = %v, want none", hunks)
+	}
+}
+
+func TestDiffStorageEqual(t *testing.T) {
+	hunks, err := DiffStorage("a\nb", "a\nb")
+	if err != nil {
+		t.Fatalf("DiffStorage: %v", err)
+	}
+	want := []DiffHunk{{DiffEqual, "a"}, {DiffEqual, "b"}}
+	if !diffHunksEqual(hunks, want) {
+		t.Fatalf("hunks = %v, want %v", hunks, want)
+	}
+}
+
+func TestDiffStorageInsertAndDelete(t *testing.T) {
+	hunks, err := DiffStorage("a\nb\nc", "a\nc\nd")
+	if err != nil {
+		t.Fatalf("DiffStorage: %v", err)
+	}
+	want := []DiffHunk{
+		{DiffEqual, "a"},
+		{DiffDelete, "b"},
+		{DiffEqual, "c"},
+		{DiffInsert, "d"},
+	}
+	if !diffHunksEqual(hunks, want) {
+		t.Fatalf("hunks = %v, want %v", hunks, want)
+	}
+}
+
+func diffHunksEqual(got, want []DiffHunk) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
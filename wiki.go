@@ -0,0 +1,209 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Wiki holds the configuration needed to talk to a Confluence instance:
+// the REST API base URL, credentials, and the HTTP client used to send
+// requests.
+type Wiki struct {
+	endPoint *url.URL
+	username string
+	token    string
+	client   *http.Client
+
+	// Timeout bounds the duration of each method call made through this
+	// Wiki, including the ...WithContext variants, where it applies on
+	// top of the caller's context. For paginated listings it bounds the
+	// whole walk, not each page fetch; the iterators' Next methods do not
+	// apply it. Zero means no per-call deadline is applied.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a request is retried after a
+	// retryable failure (429 or 5xx by default). Zero, the default,
+	// disables retries entirely.
+	MaxRetries int
+	// BaseDelay is the starting delay for exponential backoff between
+	// retries; it doubles on every attempt and is topped up with jitter
+	// in [0, BaseDelay).
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential part of the backoff delay; the
+	// jitter is added after the cap, and any Retry-After header is
+	// always honored in full. Zero means no cap.
+	MaxDelay time.Duration
+	// RetryableStatuses overrides the set of HTTP status codes that
+	// trigger a retry. Empty means the default of 429 and any 5xx.
+	RetryableStatuses []int
+
+	// AllowRetryOnWrite opts non-idempotent requests (POST) into the
+	// retry policy. Without it, a POST is only retried when it carries
+	// an Idempotency-Key header, since Confluence Cloud deduplicates
+	// content creation on that key.
+	AllowRetryOnWrite bool
+}
+
+// NewWiki creates a new *Wiki for the given Confluence REST API endpoint,
+// e.g. "https://example.atlassian.net/wiki/rest/api".
+func NewWiki(endPoint string, username string, token string) (*Wiki, error) {
+	u, err := url.ParseRequestURI(endPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wiki{
+		endPoint: u,
+		username: username,
+		token:    token,
+		client:   http.DefaultClient,
+	}, nil
+}
+
+// deadlineContext derives a context from ctx that is bounded by w.Timeout,
+// if one is configured. The returned cancel func must always be called by
+// the caller to release resources.
+func (w *Wiki) deadlineContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if w.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, w.Timeout)
+}
+
+func (w *Wiki) sendRequest(req *http.Request) ([]byte, error) {
+	res, err := w.doRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	return ioutil.ReadAll(res.Body)
+}
+
+// doRequest sends req, retrying per the configured retry policy, and
+// returns the successful response with its body unread so callers that
+// don't want it fully buffered (e.g. attachment downloads) can stream it.
+// On a non-2xx response that exhausts retries, it reads the body itself to
+// build an *httpError.
+func (w *Wiki) doRequest(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				return nil, fmt.Errorf("confluence: cannot retry %s %s: request body is not replayable", req.Method, req.URL)
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = io.NopCloser(body)
+		}
+
+		req.SetBasicAuth(w.username, w.token)
+
+		res, err := w.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res.StatusCode < 300 {
+			return res, nil
+		}
+
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		httpErr := &httpError{StatusCode: res.StatusCode, Body: body}
+		if !w.shouldRetry(req, res.StatusCode, attempt) {
+			return nil, httpErr
+		}
+
+		delay := w.retryDelay(res.Header.Get("Retry-After"), attempt)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// shouldRetry reports whether the request that produced statusCode should
+// be retried, given how many attempts have already been made.
+func (w *Wiki) shouldRetry(req *http.Request, statusCode int, attempt int) bool {
+	if attempt >= w.MaxRetries {
+		return false
+	}
+	if !w.isRetryableStatus(statusCode) {
+		return false
+	}
+	if req.GetBody == nil && req.Body != nil {
+		return false
+	}
+	if req.Method == http.MethodPost && !w.AllowRetryOnWrite && req.Header.Get("Idempotency-Key") == "" {
+		return false
+	}
+	return true
+}
+
+func (w *Wiki) isRetryableStatus(statusCode int) bool {
+	if len(w.RetryableStatuses) == 0 {
+		return statusCode == http.StatusTooManyRequests || statusCode >= 500
+	}
+	for _, s := range w.RetryableStatuses {
+		if s == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay computes how long to wait before the next attempt: the
+// greater of the server's Retry-After header (seconds or HTTP-date) and an
+// exponential backoff with jitter seeded from BaseDelay/MaxDelay.
+func (w *Wiki) retryDelay(retryAfter string, attempt int) time.Duration {
+	backoff := w.BaseDelay << attempt
+	if w.MaxDelay > 0 && backoff > w.MaxDelay {
+		backoff = w.MaxDelay
+	}
+	if w.BaseDelay > 0 {
+		backoff += time.Duration(rand.Int63n(int64(w.BaseDelay)))
+	}
+
+	if wait, ok := parseRetryAfter(retryAfter); ok && wait > backoff {
+		return wait
+	}
+	return backoff
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		return time.Until(when), true
+	}
+	return 0, false
+}
+
+// httpError is returned by sendRequest when Confluence responds with a
+// non-2xx status code.
+type httpError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("confluence: unexpected status %d: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,162 @@
+package confluence
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWiki(t *testing.T, rawURL string, configure func(*Wiki)) *Wiki {
+	t.Helper()
+
+	u, err := url.ParseRequestURI(rawURL)
+	if err != nil {
+		t.Fatalf("ParseRequestURI(%q): %v", rawURL, err)
+	}
+
+	w := &Wiki{
+		endPoint:  u,
+		username:  "user",
+		token:     "token",
+		client:    http.DefaultClient,
+		BaseDelay: time.Millisecond,
+	}
+	if configure != nil {
+		configure(w)
+	}
+	return w
+}
+
+func TestGetContentRetriesNoBodyGET(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write([]byte(`{"id":"1"}`))
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL, func(w *Wiki) { w.MaxRetries = 3 })
+
+	content, err := w.GetContent("1", nil)
+	if err != nil {
+		t.Fatalf("GetContent: %v", err)
+	}
+	if content.Id != "1" {
+		t.Fatalf("content.Id = %q, want %q", content.Id, "1")
+	}
+	if requests != 3 {
+		t.Fatalf("requests = %d, want 3", requests)
+	}
+}
+
+func TestCreateContentRetriesReplayablePOST(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 2 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write([]byte(`{"id":"2"}`))
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL, func(w *Wiki) {
+		w.MaxRetries = 3
+		w.AllowRetryOnWrite = true
+	})
+
+	content, err := w.CreateContent(&Content{Title: "new page"})
+	if err != nil {
+		t.Fatalf("CreateContent: %v", err)
+	}
+	if content.Id != "2" {
+		t.Fatalf("content.Id = %q, want %q", content.Id, "2")
+	}
+	if requests != 2 {
+		t.Fatalf("requests = %d, want 2", requests)
+	}
+}
+
+func TestAddAttachmentReaderReturnsErrorOnBadContentID(t *testing.T) {
+	w := newTestWiki(t, "http://example.invalid/wiki/rest/api", nil)
+
+	done := make(chan struct{})
+	go func() {
+		_, err := w.AddAttachmentReader("bad%id", "file.txt", strings.NewReader("data"), "")
+		if err == nil {
+			t.Error("AddAttachmentReader: expected error, got nil")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("AddAttachmentReader did not return: upload goroutine leaked on a bad endpoint")
+	}
+}
+
+func TestUpdateAttachmentDataReturnsErrorOnBadContentID(t *testing.T) {
+	w := newTestWiki(t, "http://example.invalid/wiki/rest/api", nil)
+
+	done := make(chan struct{})
+	go func() {
+		_, err := w.UpdateAttachmentData("bad%id", "1", strings.NewReader("data"), "file.txt", "")
+		if err == nil {
+			t.Error("UpdateAttachmentData: expected error, got nil")
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("UpdateAttachmentData did not return: upload goroutine leaked on a bad endpoint")
+	}
+}
+
+func TestAddAttachmentsFailsOnUnopenableFile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		rw.Write([]byte(`{"id":"1"}`))
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL, nil)
+
+	_, err := w.AddAttachments(&Content{Id: "1", Attachments: []string{"/nonexistent/file/does/not/exist"}})
+	if err == nil {
+		t.Fatal("AddAttachments: expected error for unopenable file, got nil")
+	}
+}
+
+func TestAddAttachmentReaderDoesNotRetryStreamedPOST(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	w := newTestWiki(t, server.URL, func(w *Wiki) {
+		w.MaxRetries = 3
+		w.AllowRetryOnWrite = true
+	})
+
+	_, err := w.AddAttachmentReader("1", "file.txt", strings.NewReader("data"), "")
+	if err == nil {
+		t.Fatal("AddAttachmentReader: expected error, got nil")
+	}
+	if requests != 1 {
+		t.Fatalf("requests = %d, want 1 (streamed body must not be retried)", requests)
+	}
+}